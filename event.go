@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/urfave/cli/v3"
+)
+
+// eventCmd builds, signs and optionally publishes a Nostr event. Plain
+// signing is handled directly; when --musig2 is given, signing is instead
+// driven by runMusig2EventFlags (musig2_cli.go), which picks between the
+// plain multi-round ceremony, the --musig2-coordinate relay transport, and
+// the --musig2-state file transport depending on which flags are set.
+var eventCmd = &cli.Command{
+	Name:                      "event",
+	Usage:                     "creates, signs and optionally publishes a Nostr event",
+	DisableSliceFlagSeparator: true,
+	Flags: append([]cli.Flag{
+		&cli.IntFlag{
+			Name:    "kind",
+			Aliases: []string{"k"},
+			Usage:   "event kind",
+			Value:   1,
+		},
+		&cli.StringFlag{
+			Name:    "content",
+			Aliases: []string{"c"},
+			Usage:   "event content",
+		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Aliases: []string{"t"},
+			Usage:   "a tag in 'key=value,value2,...' format (repeatable)",
+		},
+		&cli.Int64Flag{
+			Name:    "created-at",
+			Aliases: []string{"ts"},
+			Usage:   "unix timestamp for the event (defaults to now)",
+		},
+		&cli.StringFlag{
+			Name:  "sec",
+			Usage: "secret key to sign with, as hex or nsec",
+		},
+		&cli.StringFlag{
+			Name:  "relay",
+			Usage: "relay to publish the signed event to, if any",
+		},
+	}, musig2EventFlags...),
+	Action: func(ctx context.Context, c *cli.Command) error {
+		evt := nostr.Event{
+			Kind:    int(c.Int("kind")),
+			Content: c.String("content"),
+		}
+		if ts := c.Int64("created-at"); ts != 0 {
+			evt.CreatedAt = nostr.Timestamp(ts)
+		} else {
+			evt.CreatedAt = nostr.Now()
+		}
+		for _, t := range c.StringSlice("tag").Value() {
+			parts := strings.SplitN(t, "=", 2)
+			tag := nostr.Tag{parts[0]}
+			if len(parts) > 1 {
+				tag = append(tag, strings.Split(parts[1], ",")...)
+			}
+			evt.Tags = append(evt.Tags, tag)
+		}
+
+		sec := c.String("sec")
+
+		handled, signed, err := runMusig2EventFlags(ctx, c, &evt)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			if err := evt.Sign(sec); err != nil {
+				return fmt.Errorf("failed to sign event: %w", err)
+			}
+			signed = true
+		}
+		if !signed {
+			// --musig2 ceremony still waiting on other signers: nothing to
+			// print or publish yet, the next round was already printed or
+			// persisted by runMusig2EventFlags
+			return nil
+		}
+		if handled && c.String("musig2-message") != "" {
+			// the signature over --musig2-message was already printed on
+			// stdout by performMusig, there is no signed event to output
+			return nil
+		}
+
+		fmt.Println(evt)
+
+		if relay := c.String("relay"); relay != "" {
+			for res := range sys.Pool.PublishMany(ctx, []string{relay}, evt) {
+				if res.Error != nil {
+					return fmt.Errorf("failed to publish to %s: %w", res.RelayURL, res.Error)
+				}
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nbd-wtf/go-nostr/sdk"
+	"github.com/urfave/cli/v3"
+)
+
+var version = "v0.0.0"
+
+var sys = sdk.NewSystem()
+
+func main() {
+	app := &cli.Command{
+		Name:  "nak",
+		Usage: "the nostr army knife",
+		Commands: []*cli.Command{
+			eventCmd,
+			mcpServer,
+			musig2Cmd,
+		},
+	}
+
+	if err := app.Run(context.Background(), os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/nbd-wtf/go-nostr/nip59"
 	"github.com/nbd-wtf/go-nostr/sdk"
 	"github.com/urfave/cli/v3"
 )
@@ -126,7 +135,7 @@ var mcpServer = &cli.Command{
 					fmt.Sprintf("this is a Nostr profile named '%s', their public key is '%s'",
 						pm.ShortName(), pm.PubKey),
 				), nil
-			case "nevent":
+			case "nevent", "naddr":
 				event, _, err := sys.FetchSpecificEventFromInput(ctx, uri, sdk.FetchSpecificEventParameters{
 					WithRelays: false,
 				})
@@ -137,8 +146,6 @@ var mcpServer = &cli.Command{
 				return mcp.NewToolResultText(
 					fmt.Sprintf("this is a Nostr event: %s", event),
 				), nil
-			case "naddr":
-				return mcp.NewToolResultError("For now we can't handle this kind of Nostr uri"), nil
 			default:
 				return mcp.NewToolResultError("We don't know how to handle this Nostr uri"), nil
 			}
@@ -205,6 +212,242 @@ var mcpServer = &cli.Command{
 			return mcp.NewToolResultText(result.String()), nil
 		})
 
+		s.AddTool(mcp.NewTool("send_direct_message",
+			mcp.WithDescription("Send a NIP-17 private direct message to a Nostr user"),
+			mcp.WithString("recipient", mcp.Description("Public key of the user to message"), mcp.Required()),
+			mcp.WithString("content", mcp.Description("Message text"), mcp.Required()),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			recipient := required[string](r, "recipient")
+			content := required[string](r, "content")
+
+			if !nostr.IsValidPublicKey(recipient) {
+				return mcp.NewToolResultError("the given recipient isn't a valid public key, it must be 32 bytes hex, like the ones returned by search_profile"), nil
+			}
+
+			sk := os.Getenv("NOSTR_SECRET_KEY")
+			if sk == "" {
+				return mcp.NewToolResultError("NOSTR_SECRET_KEY must be set to send direct messages"), nil
+			}
+			ourPubkey, err := nostr.GetPublicKey(sk)
+			if err != nil {
+				return mcp.NewToolResultError("invalid NOSTR_SECRET_KEY: " + err.Error()), nil
+			}
+
+			rumor := nostr.Event{
+				Kind:      14,
+				PubKey:    ourPubkey,
+				Content:   content,
+				Tags:      nostr.Tags{{"p", recipient}},
+				CreatedAt: nostr.Now(),
+			}
+
+			wrapToThem, err := nip59.GiftWrap(rumor, recipient, sk)
+			if err != nil {
+				return mcp.NewToolResultError("failed to gift wrap the message: " + err.Error()), nil
+			}
+			wrapToUs, err := nip59.GiftWrap(rumor, ourPubkey, sk)
+			if err != nil {
+				return mcp.NewToolResultError("failed to gift wrap our own copy: " + err.Error()), nil
+			}
+
+			relays := fetchDMRelays(ctx, recipient)
+			if len(relays) == 0 {
+				relays = []string{"nos.lol", "relay.damus.io"}
+			}
+			ourRelays := fetchDMRelays(ctx, ourPubkey)
+			if len(ourRelays) == 0 {
+				ourRelays = relays
+			}
+
+			result := strings.Builder{}
+			for res := range sys.Pool.PublishMany(ctx, relays, *wrapToThem) {
+				if res.Error != nil {
+					result.WriteString(fmt.Sprintf("failed to publish to %s. ", res.RelayURL))
+				} else {
+					result.WriteString(fmt.Sprintf("delivered to %s. ", res.RelayURL))
+				}
+			}
+			for res := range sys.Pool.PublishMany(ctx, ourRelays, *wrapToUs) {
+				if res.Error != nil {
+					result.WriteString(fmt.Sprintf("failed to save our own copy to %s. ", res.RelayURL))
+				} else {
+					result.WriteString(fmt.Sprintf("saved our own copy to %s. ", res.RelayURL))
+				}
+			}
+
+			return mcp.NewToolResultText(result.String()), nil
+		})
+
+		s.AddTool(mcp.NewTool("send_zap",
+			mcp.WithDescription("Zap (pay over Lightning, with a Nostr receipt) a Nostr user, returns a BOLT-11 invoice that must be paid to complete the zap"),
+			mcp.WithString("pubkey", mcp.Description("Public key of the user to zap"), mcp.Required()),
+			mcp.WithNumber("sats", mcp.Description("Amount to zap, in satoshis"), mcp.Required()),
+			mcp.WithString("comment", mcp.Description("Optional comment to attach to the zap")),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			pubkey := required[string](r, "pubkey")
+			sats := int64(required[float64](r, "sats"))
+			comment, _ := optional[string](r, "comment")
+
+			if !nostr.IsValidPublicKey(pubkey) {
+				return mcp.NewToolResultError("the given pubkey isn't a valid public key, it must be 32 bytes hex, like the ones returned by search_profile"), nil
+			}
+			if sats <= 0 {
+				return mcp.NewToolResultError("sats must be a positive amount"), nil
+			}
+
+			pm := sys.FetchProfileMetadata(ctx, pubkey)
+			lnaddr := pm.Lud16
+			if lnaddr == "" {
+				lnaddr = pm.Lud06
+			}
+			if lnaddr == "" {
+				return mcp.NewToolResultError("this user hasn't set up a lightning address (lud16/lud06) on their profile"), nil
+			}
+
+			params, err := fetchLNURLPayParams(lnaddr)
+			if err != nil {
+				return mcp.NewToolResultError("failed to resolve this user's lightning address: " + err.Error()), nil
+			}
+			if !params.AllowsNostr || params.NostrPubkey == "" {
+				return mcp.NewToolResultError("this user's lightning wallet doesn't support zaps"), nil
+			}
+
+			sk := os.Getenv("NOSTR_SECRET_KEY")
+			if sk == "" {
+				sk = "0000000000000000000000000000000000000000000000000000000000000001"
+			}
+
+			zapRequest := nostr.Event{
+				Kind:    9734,
+				Content: comment,
+				Tags: nostr.Tags{
+					{"p", pubkey},
+					{"amount", strconv.FormatInt(sats*1000, 10)},
+					{"relays", "wss://nos.lol", "wss://relay.damus.io"},
+				},
+				CreatedAt: nostr.Now(),
+			}
+			zapRequest.Sign(sk)
+
+			invoice, err := fetchZapInvoice(params.Callback, zapRequest, sats*1000)
+			if err != nil {
+				return mcp.NewToolResultError("failed to get an invoice from this user's lightning wallet: " + err.Error()), nil
+			}
+
+			return mcp.NewToolResultText(
+				fmt.Sprintf("here's the BOLT-11 invoice for %d sats, pay it to complete the zap: %s", sats, invoice),
+			), nil
+		})
+
+		s.AddTool(mcp.NewTool("follow_user",
+			mcp.WithDescription("Add a Nostr user to our contact list (kind:3)"),
+			mcp.WithString("pubkey", mcp.Description("Public key of the user to follow"), mcp.Required()),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mutateContactList(ctx, r, true)
+		})
+
+		s.AddTool(mcp.NewTool("unfollow_user",
+			mcp.WithDescription("Remove a Nostr user from our contact list (kind:3)"),
+			mcp.WithString("pubkey", mcp.Description("Public key of the user to unfollow"), mcp.Required()),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mutateContactList(ctx, r, false)
+		})
+
+		s.AddTool(mcp.NewTool("subscribe_events",
+			mcp.WithDescription("Open a streaming subscription for events matching a filter on a relay; returns a handle to be read with poll_subscription and closed with close_subscription"),
+			mcp.WithString("relay", mcp.Description("relay URL to subscribe to"), mcp.Required()),
+			mcp.WithNumber("kind", mcp.Description("event kind number to include in the 'kinds' field"), mcp.Required()),
+			mcp.WithString("pubkey", mcp.Description("pubkey to include in the 'authors' field")),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			relay := required[string](r, "relay")
+			kind := int(required[float64](r, "kind"))
+			pubkey, _ := optional[string](r, "pubkey")
+
+			if pubkey != "" && !nostr.IsValidPublicKey(pubkey) {
+				return mcp.NewToolResultError("the given pubkey isn't a valid public key, it must be 32 bytes hex, like the ones returned by search_profile"), nil
+			}
+
+			filter := nostr.Filter{Kinds: []int{kind}}
+			if pubkey != "" {
+				filter.Authors = []string{pubkey}
+			}
+
+			subCtx, cancel := context.WithCancel(context.Background())
+			sub, err := sys.Pool.SubscribeMany(subCtx, []string{relay}, filter, nostr.SubscriptionOptions{})
+			if err != nil {
+				cancel()
+				return mcp.NewToolResultError("failed to subscribe: " + err.Error()), nil
+			}
+
+			handle := newSubscriptionHandle()
+			live := &liveSubscription{cancel: cancel}
+			liveSubscriptions.Lock()
+			liveSubscriptions.byHandle[handle] = live
+			liveSubscriptions.Unlock()
+
+			go func() {
+				for ie := range sub {
+					live.mu.Lock()
+					live.events = append(live.events, ie.Event)
+					if len(live.events) > 200 {
+						live.events = live.events[len(live.events)-200:]
+					}
+					live.mu.Unlock()
+				}
+			}()
+
+			return mcp.NewToolResultText(
+				fmt.Sprintf("subscription opened, handle '%s' -- call poll_subscription to read new events and close_subscription when done", handle),
+			), nil
+		})
+
+		s.AddTool(mcp.NewTool("poll_subscription",
+			mcp.WithDescription("Read and clear the events accumulated so far on a subscribe_events handle"),
+			mcp.WithString("handle", mcp.Description("handle returned by subscribe_events"), mcp.Required()),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			handle := required[string](r, "handle")
+
+			liveSubscriptions.Lock()
+			live, ok := liveSubscriptions.byHandle[handle]
+			liveSubscriptions.Unlock()
+			if !ok {
+				return mcp.NewToolResultError("no such subscription handle"), nil
+			}
+
+			live.mu.Lock()
+			events := live.events
+			live.events = nil
+			live.mu.Unlock()
+
+			if len(events) == 0 {
+				return mcp.NewToolResultText("no new events since the last poll"), nil
+			}
+
+			result := strings.Builder{}
+			for _, evt := range events {
+				result.WriteString(fmt.Sprintf("kind %d from %s: '%s'\n---\n", evt.Kind, evt.PubKey, evt.Content))
+			}
+			return mcp.NewToolResultText(result.String()), nil
+		})
+
+		s.AddTool(mcp.NewTool("close_subscription",
+			mcp.WithDescription("Close a subscription opened with subscribe_events"),
+			mcp.WithString("handle", mcp.Description("handle returned by subscribe_events"), mcp.Required()),
+		), func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			handle := required[string](r, "handle")
+
+			liveSubscriptions.Lock()
+			live, ok := liveSubscriptions.byHandle[handle]
+			delete(liveSubscriptions.byHandle, handle)
+			liveSubscriptions.Unlock()
+			if !ok {
+				return mcp.NewToolResultError("no such subscription handle"), nil
+			}
+			live.cancel()
+
+			return mcp.NewToolResultText("subscription closed"), nil
+		})
+
 		return server.ServeStdio(s)
 	},
 }
@@ -233,3 +476,182 @@ func optional[T any](r mcp.CallToolRequest, p string) (T, bool) {
 	}
 	return r.Params.Arguments[p].(T), true
 }
+
+// fetchDMRelays returns a user's NIP-17 DM relay list (kind:10050), the
+// relays a NIP-17 direct message addressed to them should be published to.
+func fetchDMRelays(ctx context.Context, pubkey string) []string {
+	re := sys.Pool.QuerySingle(ctx, []string{"relay.damus.io", "nos.lol", "purplepag.es"}, nostr.Filter{
+		Kinds:   []int{10050},
+		Authors: []string{pubkey},
+	})
+	if re == nil {
+		return nil
+	}
+
+	relays := make([]string, 0, len(re.Tags))
+	for _, tag := range re.Tags {
+		if tag.Key() == "relay" && len(tag) > 1 {
+			relays = append(relays, tag[1])
+		}
+	}
+	return relays
+}
+
+// lnurlPayParams is the subset of the LNURL-pay response (LUD-06/LUD-16,
+// extended by LUD-18 with AllowsNostr/NostrPubkey for NIP-57 zaps) we need.
+type lnurlPayParams struct {
+	Callback    string `json:"callback"`
+	AllowsNostr bool   `json:"allowsNostr"`
+	NostrPubkey string `json:"nostrPubkey"`
+}
+
+// fetchLNURLPayParams resolves a lud16 lightning address (name@domain,
+// treated as an LNURL-pay well-known endpoint) and fetches its pay params.
+func fetchLNURLPayParams(lnaddr string) (lnurlPayParams, error) {
+	at := strings.IndexByte(lnaddr, '@')
+	if at == -1 {
+		return lnurlPayParams{}, fmt.Errorf("%q is not a lud16 lightning address", lnaddr)
+	}
+	name, domain := lnaddr[:at], lnaddr[at+1:]
+	endpoint := fmt.Sprintf("https://%s/.well-known/lnurlp/%s", domain, name)
+
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return lnurlPayParams{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return lnurlPayParams{}, err
+	}
+
+	var params lnurlPayParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		return lnurlPayParams{}, fmt.Errorf("invalid LNURL-pay response: %w", err)
+	}
+	if params.Callback == "" {
+		return lnurlPayParams{}, fmt.Errorf("LNURL-pay response has no callback")
+	}
+
+	return params, nil
+}
+
+// fetchZapInvoice calls the LNURL-pay callback with the signed zap request
+// (NIP-57) and the amount in millisatoshis, and returns the BOLT-11 invoice.
+func fetchZapInvoice(callback string, zapRequest nostr.Event, msats int64) (string, error) {
+	zapJSON, err := json.Marshal(zapRequest)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(callback)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("amount", strconv.FormatInt(msats, 10))
+	q.Set("nostr", string(zapJSON))
+	u.RawQuery = q.Encode()
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		PR     string `json:"pr"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid invoice response: %w", err)
+	}
+	if result.PR == "" {
+		return "", fmt.Errorf("lightning wallet refused the zap: %s", result.Reason)
+	}
+
+	return result.PR, nil
+}
+
+// mutateContactList adds or removes a "p" tag from our kind:3 contact list
+// and republishes it.
+func mutateContactList(ctx context.Context, r mcp.CallToolRequest, add bool) (*mcp.CallToolResult, error) {
+	pubkey := required[string](r, "pubkey")
+	if !nostr.IsValidPublicKey(pubkey) {
+		return mcp.NewToolResultError("the given pubkey isn't a valid public key, it must be 32 bytes hex, like the ones returned by search_profile"), nil
+	}
+
+	sk := os.Getenv("NOSTR_SECRET_KEY")
+	if sk == "" {
+		return mcp.NewToolResultError("NOSTR_SECRET_KEY must be set to edit the contact list"), nil
+	}
+	ourPubkey, err := nostr.GetPublicKey(sk)
+	if err != nil {
+		return mcp.NewToolResultError("invalid NOSTR_SECRET_KEY: " + err.Error()), nil
+	}
+
+	relays := sys.FetchOutboxRelays(ctx, ourPubkey, 3)
+	if len(relays) == 0 {
+		relays = []string{"nos.lol", "relay.damus.io"}
+	}
+
+	evt := nostr.Event{Kind: 3, CreatedAt: nostr.Now()}
+	if current := sys.Pool.QuerySingle(ctx, relays, nostr.Filter{Kinds: []int{3}, Authors: []string{ourPubkey}}); current != nil {
+		evt.Tags = current.Tags
+		evt.Content = current.Content
+	}
+
+	alreadyFollowed := false
+	filtered := make(nostr.Tags, 0, len(evt.Tags)+1)
+	for _, tag := range evt.Tags {
+		if tag.Key() == "p" && len(tag) > 1 && tag[1] == pubkey {
+			alreadyFollowed = true
+			if !add {
+				continue // drop it: unfollow
+			}
+		}
+		filtered = append(filtered, tag)
+	}
+	if add && !alreadyFollowed {
+		filtered = append(filtered, nostr.Tag{"p", pubkey})
+	}
+	evt.Tags = filtered
+
+	evt.Sign(sk)
+
+	result := strings.Builder{}
+	for res := range sys.Pool.PublishMany(ctx, relays, evt) {
+		if res.Error != nil {
+			result.WriteString(fmt.Sprintf("failed to publish to %s: %s. ", res.RelayURL, res.Error))
+		} else {
+			result.WriteString(fmt.Sprintf("published to %s. ", res.RelayURL))
+		}
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// liveSubscription buffers events received by a subscribe_events call until
+// they're drained by poll_subscription.
+type liveSubscription struct {
+	mu     sync.Mutex
+	events []nostr.Event
+	cancel context.CancelFunc
+}
+
+var liveSubscriptions = struct {
+	sync.Mutex
+	byHandle map[string]*liveSubscription
+}{byHandle: make(map[string]*liveSubscription)}
+
+func newSubscriptionHandle() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
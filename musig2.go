@@ -19,16 +19,35 @@ func performMusig(
 	ctx context.Context,
 	sec string,
 	evt *nostr.Event,
+	message string, // hex32, alternative to evt -- exactly one of the two must be given
 	numSigners int,
 	keys []string,
 	nonces []string,
 	secNonce string,
 	partialSigs []string,
-) (signed bool, err error) {
+	tweaks []string, // hex32 tweaks, applied to the combined key in order
+	tweaksXOnly bool,
+	silent bool,
+) (signed bool, ourSecNonce string, ourPartialSig string, err error) {
 	// preprocess data received
 	secb, err := hex.DecodeString(sec)
 	if err != nil {
-		return false, err
+		return false, "", "", err
+	}
+
+	tweakDescs := make([]musig2.KeyTweakDesc, 0, len(tweaks))
+	for _, hextweak := range tweaks {
+		btweak, err := hex.DecodeString(hextweak)
+		if err != nil {
+			return false, "", "", fmt.Errorf("invalid --musig2-tweak %s: %w", hextweak, err)
+		}
+		if len(btweak) != 32 {
+			return false, "", "", fmt.Errorf("--musig2-tweak must be 32 bytes: %s", hextweak)
+		}
+		var desc musig2.KeyTweakDesc
+		copy(desc.Tweak[:], btweak)
+		desc.IsXOnly = tweaksXOnly
+		tweakDescs = append(tweakDescs, desc)
 	}
 	seck, pubk := btcec.PrivKeyFromBytes(secb)
 
@@ -37,11 +56,11 @@ func performMusig(
 	for _, hexpub := range keys {
 		bpub, err := hex.DecodeString(hexpub)
 		if err != nil {
-			return false, err
+			return false, "", "", err
 		}
 		spub, err := btcec.ParsePubKey(bpub)
 		if err != nil {
-			return false, err
+			return false, "", "", err
 		}
 		knownSigners = append(knownSigners, spub)
 
@@ -57,10 +76,10 @@ func performMusig(
 	for _, hexnonce := range nonces {
 		bnonce, err := hex.DecodeString(hexnonce)
 		if err != nil {
-			return false, err
+			return false, "", "", err
 		}
 		if len(bnonce) != 66 {
-			return false, fmt.Errorf("nonce is not 66 bytes: %s", hexnonce)
+			return false, "", "", fmt.Errorf("nonce is not 66 bytes: %s", hexnonce)
 		}
 		var b66nonce [66]byte
 		copy(b66nonce[:], bnonce)
@@ -71,34 +90,43 @@ func performMusig(
 	for _, hexps := range partialSigs {
 		bps, err := hex.DecodeString(hexps)
 		if err != nil {
-			return false, err
+			return false, "", "", err
 		}
 		var ps musig2.PartialSignature
 		if err := ps.Decode(bytes.NewBuffer(bps)); err != nil {
-			return false, fmt.Errorf("invalid partial signature %s: %w", hexps, err)
+			return false, "", "", fmt.Errorf("invalid partial signature %s: %w", hexps, err)
 		}
 		knownPartialSigs = append(knownPartialSigs, &ps)
 	}
 
 	// create the context
+	ctxOpts := make([]musig2.ContextOption, 0, 3)
+	if len(tweakDescs) > 0 {
+		ctxOpts = append(ctxOpts, musig2.WithTweakedContext(tweakDescs...))
+	}
+
 	var mctx *musig2.Context
 	if len(knownSigners) < numSigners {
 		// we don't know all the signers yet
 		mctx, err = musig2.NewContext(seck, true,
-			musig2.WithNumSigners(numSigners),
-			musig2.WithEarlyNonceGen(),
+			append(ctxOpts,
+				musig2.WithNumSigners(numSigners),
+				musig2.WithEarlyNonceGen(),
+			)...,
 		)
 		if err != nil {
-			return false, fmt.Errorf("failed to create signing context with %d unknown signers: %w",
+			return false, "", "", fmt.Errorf("failed to create signing context with %d unknown signers: %w",
 				numSigners, err)
 		}
 	} else {
 		// we know all the signers
 		mctx, err = musig2.NewContext(seck, true,
-			musig2.WithKnownSigners(knownSigners),
+			append(ctxOpts,
+				musig2.WithKnownSigners(knownSigners),
+			)...,
 		)
 		if err != nil {
-			return false, fmt.Errorf("failed to create signing context with %d known signers: %w",
+			return false, "", "", fmt.Errorf("failed to create signing context with %d known signers: %w",
 				len(knownSigners), err)
 		}
 	}
@@ -108,20 +136,25 @@ func performMusig(
 		// if we don't have all the signers we just generate a nonce and yield it to the next people
 		nonce, err := mctx.EarlySessionNonce()
 		if err != nil {
-			return false, err
+			return false, "", "", err
+		}
+		ourSecNonce = base64.StdEncoding.EncodeToString(nonce.SecNonce[:])
+		if !silent {
+			fmt.Fprintf(os.Stderr, "the following code should be saved secretly until the next step an included with --musig2-nonce-secret:\n")
+			fmt.Fprintf(os.Stderr, "%s\n\n", ourSecNonce)
 		}
-		fmt.Fprintf(os.Stderr, "the following code should be saved secretly until the next step an included with --musig2-nonce-secret:\n")
-		fmt.Fprintf(os.Stderr, "%s\n\n", base64.StdEncoding.EncodeToString(nonce.SecNonce[:]))
 
 		knownNonces = append(knownNonces, nonce.PubNonce)
-		printPublicCommandForNextPeer(evt, numSigners, knownSigners, knownNonces, nil, false)
-		return false, nil
+		if !silent {
+			printPublicCommandForNextPeer(evt, message, numSigners, knownSigners, knownNonces, nil, tweaks, tweaksXOnly, false)
+		}
+		return false, ourSecNonce, "", nil
 	}
 
 	// if we got here we have all the pubkeys, so we can print the combined key
 	if comb, err := mctx.CombinedKey(); err != nil {
-		return false, fmt.Errorf("failed to combine keys (after %d signers): %w", len(knownSigners), err)
-	} else {
+		return false, "", "", fmt.Errorf("failed to combine keys (after %d signers): %w", len(knownSigners), err)
+	} else if !silent {
 		fmt.Fprintf(os.Stderr, "combined key: %x\n\n", comb.SerializeCompressed())
 	}
 
@@ -132,18 +165,18 @@ func performMusig(
 		// i.e. we didn't input our own pub nonce in the parameters
 		session, err = mctx.NewSession()
 		if err != nil {
-			return false, fmt.Errorf("failed to create session as the last peer to include our key: %w", err)
+			return false, "", "", fmt.Errorf("failed to create session as the last peer to include our key: %w", err)
 		}
 		knownNonces = append(knownNonces, session.PublicNonce())
 	} else {
 		// otherwise we have included our own nonce in the parameters (from copypasting) but must
 		// also include the secret nonce that wasn't shared with peers
 		if secNonce == "" {
-			return false, fmt.Errorf("missing --musig2-nonce-secret value")
+			return false, "", "", fmt.Errorf("missing --musig2-nonce-secret value")
 		}
 		secNonceB, err := base64.StdEncoding.DecodeString(secNonce)
 		if err != nil {
-			return false, fmt.Errorf("invalid --musig2-nonce-secret: %w", err)
+			return false, "", "", fmt.Errorf("invalid --musig2-nonce-secret: %w", err)
 		}
 		var secNonce97 [97]byte
 		copy(secNonce97[:], secNonceB)
@@ -152,7 +185,7 @@ func performMusig(
 			PubNonce: secNonceToPubNonce(secNonce97),
 		}))
 		if err != nil {
-			return false, fmt.Errorf("failed to create signing session with secret nonce: %w", err)
+			return false, "", "", fmt.Errorf("failed to create signing session with secret nonce: %w", err)
 		}
 	}
 
@@ -165,51 +198,77 @@ func performMusig(
 
 		noncesOk, err = session.RegisterPubNonce(b66nonce)
 		if err != nil {
-			return false, fmt.Errorf("failed to register nonce: %w", err)
+			return false, "", "", fmt.Errorf("failed to register nonce: %w", err)
 		}
 	}
 	if !noncesOk {
-		return false, fmt.Errorf("we've registered all the nonces we had but at least one is missing, this shouldn't happen")
+		return false, "", "", fmt.Errorf("we've registered all the nonces we had but at least one is missing, this shouldn't happen")
 	}
 
 	// signing phase
 	// we always have to sign, so let's do this
-	id := evt.GetID()
-	hash, _ := hex.DecodeString(id)
 	var msg32 [32]byte
-	copy(msg32[:], hash)
+	if evt != nil {
+		hash, _ := hex.DecodeString(evt.GetID())
+		copy(msg32[:], hash)
+	} else {
+		hash, err := hex.DecodeString(message)
+		if err != nil {
+			return false, "", "", fmt.Errorf("invalid --musig2-message: %w", err)
+		}
+		if len(hash) != 32 {
+			return false, "", "", fmt.Errorf("--musig2-message must be 32 bytes: %s", message)
+		}
+		copy(msg32[:], hash)
+	}
 	partialSig, err := session.Sign(msg32) // this will already include our sig in the bundle
 	if err != nil {
-		return false, fmt.Errorf("failed to produce partial signature: %w", err)
+		return false, "", "", fmt.Errorf("failed to produce partial signature: %w", err)
 	}
 
+	ourPartialSigBuf := &bytes.Buffer{}
+	if err := partialSig.Encode(ourPartialSigBuf); err != nil {
+		return false, "", "", fmt.Errorf("failed to encode our own partial signature: %w", err)
+	}
+	ourPartialSig = hex.EncodeToString(ourPartialSigBuf.Bytes())
+
 	if len(knownPartialSigs)+1 < len(knownSigners) {
 		// still missing some signatures
 		knownPartialSigs = append(knownPartialSigs, partialSig) // we include ours here just so it's printed
-		printPublicCommandForNextPeer(evt, numSigners, knownSigners, knownNonces, knownPartialSigs, true)
-		return false, nil
+		if !silent {
+			printPublicCommandForNextPeer(evt, message, numSigners, knownSigners, knownNonces, knownPartialSigs, tweaks, tweaksXOnly, true)
+		}
+		return false, "", ourPartialSig, nil
 	} else {
 		// we have all signatures
 		for _, ps := range knownPartialSigs {
 			_, err = session.CombineSig(ps)
 			if err != nil {
-				return false, fmt.Errorf("failed to combine partial signature: %w", err)
+				return false, "", "", fmt.Errorf("failed to combine partial signature: %w", err)
 			}
 		}
 	}
 
 	// we have the signature
-	evt.Sig = hex.EncodeToString(session.FinalSig().Serialize())
+	finalSig := hex.EncodeToString(session.FinalSig().Serialize())
+	if evt != nil {
+		evt.Sig = finalSig
+	} else {
+		fmt.Println(finalSig)
+	}
 
-	return true, nil
+	return true, "", "", nil
 }
 
 func printPublicCommandForNextPeer(
 	evt *nostr.Event,
+	message string,
 	numSigners int,
 	knownSigners []*btcec.PublicKey,
 	knownNonces [][66]byte,
 	knownPartialSigs []*musig2.PartialSignature,
+	tweaks []string,
+	tweaksXOnly bool,
 	includeNonceSecret bool,
 ) {
 	maybeNonceSecret := ""
@@ -217,12 +276,20 @@ func printPublicCommandForNextPeer(
 		maybeNonceSecret = " --musig2-nonce-secret '<their-nonce-secret>'"
 	}
 
-	fmt.Fprintf(os.Stderr, "the next signer and they should call this on their side:\nnak event --sec <their-key> --musig2 %d %s%s%s%s%s\n",
+	var payloadArgs string
+	if evt != nil {
+		payloadArgs = eventToCliArgs(evt)
+	} else {
+		payloadArgs = messageToCliArgs(message)
+	}
+
+	fmt.Fprintf(os.Stderr, "the next signer and they should call this on their side:\nnak event --sec <their-key> --musig2 %d %s%s%s%s%s%s\n",
 		numSigners,
-		eventToCliArgs(evt),
+		payloadArgs,
 		signersToCliArgs(knownSigners),
 		noncesToCliArgs(knownNonces),
 		partialSigsToCliArgs(knownPartialSigs),
+		tweaksToCliArgs(tweaks, tweaksXOnly),
 		maybeNonceSecret,
 	)
 }
@@ -260,6 +327,31 @@ func eventToCliArgs(evt *nostr.Event) string {
 	return b.String()
 }
 
+func messageToCliArgs(message string) string {
+	b := strings.Builder{}
+	b.Grow(20 + len(message))
+
+	b.WriteString("--musig2-message ")
+	b.WriteString(message)
+
+	return b.String()
+}
+
+func tweaksToCliArgs(tweaks []string, tweaksXOnly bool) string {
+	b := strings.Builder{}
+	b.Grow(len(tweaks) * (16 + 64))
+
+	for _, tweak := range tweaks {
+		b.WriteString(" --musig2-tweak ")
+		b.WriteString(tweak)
+	}
+	if len(tweaks) > 0 && tweaksXOnly {
+		b.WriteString(" --musig2-tweak-xonly")
+	}
+
+	return b.String()
+}
+
 func signersToCliArgs(knownSigners []*btcec.PublicKey) string {
 	b := strings.Builder{}
 	b.Grow(len(knownSigners) * (17 + 66))
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/urfave/cli/v3"
+)
+
+// musig2Cmd groups the musig2 subcommands that don't belong under `nak
+// event` (which is where the signing ceremony itself, --musig2, lives).
+var musig2Cmd = &cli.Command{
+	Name:  "musig2",
+	Usage: "MuSig2 helpers that don't require a signing ceremony",
+	Commands: []*cli.Command{
+		musig2AggregateKey,
+	},
+}
+
+var musig2AggregateKey = &cli.Command{
+	Name:                      "aggregate-key",
+	Usage:                     "compute the MuSig2 aggregated public key for a set of signers",
+	Description:               `given --musig2-pubkey hex arguments (and optionally --musig2-tweak/--musig2-tweak-xonly), prints the BIP-327 aggregated key in hex, x-only and npub form -- without running a signing ceremony.`,
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "musig2-pubkey",
+			Usage:    "a signer's 33-byte compressed public key, in hex (repeat for each signer)",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "musig2-tweak",
+			Usage: "a 32-byte scalar tweak to apply to the aggregated key, in hex (repeatable, applied in order)",
+		},
+		&cli.BoolFlag{
+			Name:  "musig2-tweak-xonly",
+			Usage: "treat the tweaks as BIP-341 x-only (taproot) tweaks instead of plain tweaks",
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		pubkeys := make([]*btcec.PublicKey, 0, c.StringSlice("musig2-pubkey").Len())
+		for _, hexpub := range c.StringSlice("musig2-pubkey").Value() {
+			bpub, err := hex.DecodeString(hexpub)
+			if err != nil {
+				return fmt.Errorf("invalid --musig2-pubkey %s: %w", hexpub, err)
+			}
+			pub, err := btcec.ParsePubKey(bpub)
+			if err != nil {
+				return fmt.Errorf("invalid --musig2-pubkey %s: %w", hexpub, err)
+			}
+			pubkeys = append(pubkeys, pub)
+		}
+
+		agg, err := aggregateMusigKeys(pubkeys)
+		if err != nil {
+			return fmt.Errorf("failed to aggregate keys: %w", err)
+		}
+
+		xonly := c.Bool("musig2-tweak-xonly")
+		for _, hextweak := range c.StringSlice("musig2-tweak").Value() {
+			btweak, err := hex.DecodeString(hextweak)
+			if err != nil {
+				return fmt.Errorf("invalid --musig2-tweak %s: %w", hextweak, err)
+			}
+			if len(btweak) != 32 {
+				return fmt.Errorf("--musig2-tweak must be 32 bytes: %s", hextweak)
+			}
+			var tweak32 [32]byte
+			copy(tweak32[:], btweak)
+
+			agg, err = applyMusigTweak(agg, tweak32, xonly)
+			if err != nil {
+				return fmt.Errorf("failed to apply tweak %s: %w", hextweak, err)
+			}
+		}
+
+		xonlyHex := hex.EncodeToString(schnorrSerialize(agg))
+		npub, err := nip19.EncodePublicKey(xonlyHex)
+		if err != nil {
+			return fmt.Errorf("failed to encode npub: %w", err)
+		}
+
+		fmt.Println("compressed:", hex.EncodeToString(agg.SerializeCompressed()))
+		fmt.Println("x-only:    ", xonlyHex)
+		fmt.Println("npub:      ", npub)
+
+		return nil
+	},
+}
+
+// aggregateMusigKeys computes the BIP-327 KeyAgg of pubkeys: sorts them
+// lexicographically by compressed encoding, derives the list hash L, then
+// sums each key weighted by its KeyAgg coefficient (with the standard
+// optimization that the second unique key gets coefficient 1).
+func aggregateMusigKeys(pubkeys []*btcec.PublicKey) (*btcec.PublicKey, error) {
+	if len(pubkeys) == 0 {
+		return nil, fmt.Errorf("no public keys given")
+	}
+
+	sorted := make([]*btcec.PublicKey, len(pubkeys))
+	copy(sorted, pubkeys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(), sorted[j].SerializeCompressed()) < 0
+	})
+
+	list := make([]byte, 0, len(sorted)*33)
+	for _, p := range sorted {
+		list = append(list, p.SerializeCompressed()...)
+	}
+	l := chainhash.TaggedHash([]byte("KeyAgg list"), list)
+
+	firstKey := sorted[0].SerializeCompressed()
+	var secondUniqueKey []byte
+	for _, p := range sorted[1:] {
+		pb := p.SerializeCompressed()
+		if !bytes.Equal(pb, firstKey) {
+			secondUniqueKey = pb
+			break
+		}
+	}
+
+	var acc btcec.JacobianPoint // point at infinity
+	for _, p := range sorted {
+		pb := p.SerializeCompressed()
+
+		var coeff btcec.ModNScalar
+		if secondUniqueKey != nil && bytes.Equal(pb, secondUniqueKey) {
+			coeff.SetInt(1)
+		} else {
+			h := chainhash.TaggedHash([]byte("KeyAgg coefficient"), l[:], pb)
+			coeff.SetByteSlice(h[:])
+		}
+
+		var point, term btcec.JacobianPoint
+		p.AsJacobian(&point)
+		btcec.ScalarMultNonConst(&coeff, &point, &term)
+		btcec.AddNonConst(&acc, &term, &acc)
+	}
+
+	acc.ToAffine()
+	return btcec.NewPublicKey(&acc.X, &acc.Y), nil
+}
+
+// applyMusigTweak applies a single tweak to agg, following BIP-341 when
+// xonly is set: the point is first normalized to have an even y-coordinate
+// (negating it if needed) before the tweak is added, so every signer ends up
+// agreeing on the same sign.
+func applyMusigTweak(agg *btcec.PublicKey, tweak [32]byte, xonly bool) (*btcec.PublicKey, error) {
+	var point btcec.JacobianPoint
+	agg.AsJacobian(&point)
+	point.ToAffine()
+
+	if xonly && point.Y.IsOdd() {
+		point.Y.Negate(1)
+		point.Y.Normalize()
+	}
+
+	var t btcec.ModNScalar
+	if overflow := t.SetBytes(&tweak); overflow != 0 {
+		return nil, fmt.Errorf("tweak is not a valid scalar")
+	}
+
+	var tG, result btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&t, &tG)
+	point.Z.SetInt(1)
+	btcec.AddNonConst(&point, &tG, &result)
+
+	result.ToAffine()
+	return btcec.NewPublicKey(&result.X, &result.Y), nil
+}
+
+// schnorrSerialize returns the 32-byte x-only (BIP-340) encoding of pub.
+func schnorrSerialize(pub *btcec.PublicKey) []byte {
+	return pub.SerializeCompressed()[1:]
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+func TestAggregateMusigKeysMatchesLibrary(t *testing.T) {
+	sks := make([]*btcec.PrivateKey, 3)
+	pubkeys := make([]*btcec.PublicKey, 3)
+	for i := range sks {
+		sk, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key %d: %v", i, err)
+		}
+		sks[i] = sk
+		pubkeys[i] = sk.PubKey()
+	}
+
+	got, err := aggregateMusigKeys(pubkeys)
+	if err != nil {
+		t.Fatalf("aggregateMusigKeys: %v", err)
+	}
+
+	mctx, err := musig2.NewContext(sks[0], true, musig2.WithKnownSigners(pubkeys))
+	if err != nil {
+		t.Fatalf("musig2.NewContext: %v", err)
+	}
+	want, err := mctx.CombinedKey()
+	if err != nil {
+		t.Fatalf("CombinedKey: %v", err)
+	}
+
+	if !got.IsEqual(want) {
+		t.Fatalf("aggregateMusigKeys = %x, want %x (from btcec/musig2 itself)",
+			got.SerializeCompressed(), want.SerializeCompressed())
+	}
+}
+
+// TestAggregateMusigKeysDuplicateSigner exercises the BIP-327 "second unique
+// key gets coefficient 1" rule, which only kicks in when the sorted list
+// contains a repeated key.
+func TestAggregateMusigKeysDuplicateSigner(t *testing.T) {
+	sks := make([]*btcec.PrivateKey, 2)
+	pubkeys := make([]*btcec.PublicKey, 2)
+	for i := range sks {
+		sk, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key %d: %v", i, err)
+		}
+		sks[i] = sk
+		pubkeys[i] = sk.PubKey()
+	}
+	withDup := []*btcec.PublicKey{pubkeys[0], pubkeys[0], pubkeys[1]}
+
+	got, err := aggregateMusigKeys(withDup)
+	if err != nil {
+		t.Fatalf("aggregateMusigKeys: %v", err)
+	}
+
+	mctx, err := musig2.NewContext(sks[0], true, musig2.WithKnownSigners(withDup))
+	if err != nil {
+		t.Fatalf("musig2.NewContext: %v", err)
+	}
+	want, err := mctx.CombinedKey()
+	if err != nil {
+		t.Fatalf("CombinedKey: %v", err)
+	}
+
+	if !got.IsEqual(want) {
+		t.Fatalf("aggregateMusigKeys (with duplicate signer) = %x, want %x",
+			got.SerializeCompressed(), want.SerializeCompressed())
+	}
+}
+
+// TestApplyMusigTweakPlain checks the non-xonly path adds t*G directly with
+// no y-parity normalization, by recomputing the same addition independently.
+func TestApplyMusigTweakPlain(t *testing.T) {
+	sk, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	agg := sk.PubKey()
+
+	var tweak [32]byte
+	tweak[31] = 7
+
+	got, err := applyMusigTweak(agg, tweak, false)
+	if err != nil {
+		t.Fatalf("applyMusigTweak: %v", err)
+	}
+
+	var seven btcec.ModNScalar
+	seven.SetInt(7)
+
+	var aggJ, tG, wantJ btcec.JacobianPoint
+	agg.AsJacobian(&aggJ)
+	aggJ.Z.SetInt(1)
+	btcec.ScalarBaseMultNonConst(&seven, &tG)
+	btcec.AddNonConst(&aggJ, &tG, &wantJ)
+	wantJ.ToAffine()
+	want := btcec.NewPublicKey(&wantJ.X, &wantJ.Y)
+
+	if !got.IsEqual(want) {
+		t.Fatalf("applyMusigTweak(plain) = %x, want %x", got.SerializeCompressed(), want.SerializeCompressed())
+	}
+}
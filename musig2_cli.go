@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/urfave/cli/v3"
+)
+
+// musig2EventFlags are the --musig2* flags `nak event` needs to expose to
+// reach any of the musig2 signing paths (the plain multi-round ceremony from
+// performMusig, the gift-wrapped relay transport from performMusigCoordinate,
+// and the --musig2-state file transport from performMusigWithState).
+// eventCmd (event.go) appends this slice to its own flags, and its Action
+// calls runMusig2EventFlags before falling back to its normal single-signer
+// signing path.
+var musig2EventFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:  "musig2",
+		Usage: "enable MuSig2 signing with this many total signers instead of signing alone",
+	},
+	&cli.StringSliceFlag{
+		Name:  "musig2-pubkey",
+		Usage: "a co-signer's 33-byte compressed public key, in hex (repeatable)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "musig2-nonce",
+		Usage: "a co-signer's 66-byte public nonce, in hex (repeatable)",
+	},
+	&cli.StringFlag{
+		Name:  "musig2-nonce-secret",
+		Usage: "our own secret nonce (base64) from a previous round, when not using --musig2-state",
+	},
+	&cli.StringSliceFlag{
+		Name:  "musig2-partial",
+		Usage: "a co-signer's partial signature, in hex (repeatable)",
+	},
+	&cli.StringFlag{
+		Name:  "musig2-message",
+		Usage: "sign this 32-byte hex message instead of the event id (prints the raw signature on stdout)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "musig2-tweak",
+		Usage: "a 32-byte scalar tweak to apply to the combined key, in hex (repeatable, applied in order)",
+	},
+	&cli.BoolFlag{
+		Name:  "musig2-tweak-xonly",
+		Usage: "treat --musig2-tweak values as BIP-341 x-only (taproot) tweaks instead of plain tweaks",
+	},
+	&cli.StringFlag{
+		Name:  "musig2-coordinate",
+		Usage: "relay url: shuttle this round over NIP-59 gift-wrapped events instead of copy-pasting CLI commands",
+	},
+	&cli.StringFlag{
+		Name:  "musig2-session",
+		Usage: "session id for --musig2-coordinate, shared out-of-band between signers",
+	},
+	&cli.StringFlag{
+		Name:  "musig2-state",
+		Usage: "path to a file that persists round state between invocations instead of printing secrets to stderr",
+	},
+}
+
+// runMusig2EventFlags inspects c's --musig2* flags and, if --musig2 was
+// given, drives the appropriate performMusig/performMusigCoordinate/
+// performMusigWithState call on evt's behalf. handled is false when --musig2
+// wasn't given at all, so the caller should fall back to its normal
+// single-signer signing path.
+func runMusig2EventFlags(ctx context.Context, c *cli.Command, evt *nostr.Event) (handled bool, signed bool, err error) {
+	numSigners := int(c.Int("musig2"))
+	if numSigners == 0 {
+		return false, false, nil
+	}
+
+	sec := c.String("sec")
+	message := c.String("musig2-message")
+
+	evtArg := evt
+	if message != "" {
+		evtArg = nil
+	}
+
+	pubkeys := c.StringSlice("musig2-pubkey").Value()
+	nonces := c.StringSlice("musig2-nonce").Value()
+	partialSigs := c.StringSlice("musig2-partial").Value()
+	tweaks := c.StringSlice("musig2-tweak").Value()
+	tweaksXOnly := c.Bool("musig2-tweak-xonly")
+
+	switch {
+	case c.String("musig2-coordinate") != "":
+		signed, err = performMusigCoordinate(
+			ctx, sec, evtArg, numSigners,
+			c.String("musig2-coordinate"), c.String("musig2-session"),
+		)
+	case c.String("musig2-state") != "":
+		signed, err = performMusigWithState(
+			ctx, sec, evtArg, message, numSigners,
+			pubkeys, nonces, partialSigs,
+			tweaks, tweaksXOnly, c.String("musig2-state"),
+		)
+	default:
+		signed, _, _, err = performMusig(
+			ctx, sec, evtArg, message, numSigners,
+			pubkeys, nonces, c.String("musig2-nonce-secret"), partialSigs,
+			tweaks, tweaksXOnly, false,
+		)
+	}
+	if err != nil {
+		return true, false, fmt.Errorf("musig2: %w", err)
+	}
+
+	return true, signed, nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip59"
+)
+
+// musigCoordKind is the ephemeral gift wrap kind used to shuttle musig2 round
+// data between signers. It lives in the same range as NIP-59 gift wraps
+// (1059) but ephemeral (per NIP-16/NIP-01 "ephemeral" ranges) so relays don't
+// need to store these once a ceremony is done.
+const musigCoordKind = 21059
+
+// musigWireMessage is the payload carried inside the sealed rumor of a
+// coordination gift wrap. It mirrors exactly what performMusig already
+// accumulates in-memory (knownSigners, knownNonces, knownPartialSigs) so a
+// received wrap can be unwrapped straight into another performMusig call.
+type musigWireMessage struct {
+	SessionID  string       `json:"session_id"`
+	NumSigners int          `json:"num_signers"`
+	Event      *nostr.Event `json:"event,omitempty"`
+	Pubkey     string       `json:"pubkey"`
+	Nonce      string       `json:"nonce,omitempty"`
+	PartialSig string       `json:"partial_sig,omitempty"`
+}
+
+// performMusigCoordinate drives the same state machine as performMusig, but
+// instead of asking the user to copy-paste `nak event --musig2 ...` between
+// terminals it shuttles everything through gift-wrapped (NIP-59) ephemeral
+// events tagged with sessionID on relay. Each round it runs performMusig
+// against whatever we know so far, publishes whatever new contribution that
+// round produced (our pub nonce, or later our partial signature -- the first
+// publish also carries the draft event, acting as the "session open"), then
+// waits for the next peer's wrap before looping back around. It returns only
+// once the event is fully signed, or with an error (including when the relay
+// subscription ends before the next wrap arrives).
+func performMusigCoordinate(
+	ctx context.Context,
+	sec string,
+	evt *nostr.Event,
+	numSigners int,
+	relay string,
+	sessionID string,
+) (signed bool, err error) {
+	secb, err := hex.DecodeString(sec)
+	if err != nil {
+		return false, err
+	}
+	_, pubk := btcec.PrivKeyFromBytes(secb)
+	ourPubkey := hex.EncodeToString(pubk.SerializeCompressed())
+
+	sess, err := loadMusigSession(sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load musig2 session %q: %w", sessionID, err)
+	}
+	isSessionOpen := sess == nil
+	if isSessionOpen {
+		sess = &musigSession{
+			SessionID:  sessionID,
+			Event:      evt,
+			NumSigners: numSigners,
+			OurPubkey:  ourPubkey,
+		}
+	}
+
+	for {
+		signed, secNonce, partialSig, err := performMusig(
+			ctx, sec, sess.Event, "", sess.NumSigners,
+			sess.Pubkeys, sess.Nonces, sess.OurSecNonce, sess.PartialSigs,
+			nil, false,
+			true, // silent: the secret nonce goes straight into the session file, never to stderr
+		)
+		if err != nil {
+			return false, err
+		}
+		if secNonce != "" {
+			sess.OurSecNonce = secNonce
+		}
+		if err := saveMusigSession(sess); err != nil {
+			return false, fmt.Errorf("failed to save musig2 session %q: %w", sessionID, err)
+		}
+		if signed {
+			return true, nil
+		}
+
+		// publish whatever this round produced -- a pub nonce (derived from
+		// the secret nonce we just got back) while signers are still joining,
+		// or our partial signature once all signers and nonces are known
+		wireMsg := musigWireMessage{
+			SessionID:  sessionID,
+			NumSigners: sess.NumSigners,
+			Pubkey:     ourPubkey,
+			PartialSig: partialSig,
+		}
+		if isSessionOpen {
+			wireMsg.Event = sess.Event
+		}
+		if secNonce != "" {
+			ourNonce, err := pubNonceFromSecNonce(secNonce)
+			if err != nil {
+				return false, fmt.Errorf("failed to derive our own pub nonce for session %q: %w", sessionID, err)
+			}
+			wireMsg.Nonce = ourNonce
+		}
+		if err := publishMusigWrap(ctx, relay, sessionID, sec, wireMsg); err != nil {
+			return false, fmt.Errorf("failed to publish musig2 contribution for session %q: %w", sessionID, err)
+		}
+		isSessionOpen = false
+
+		// wait for the next wrap addressed to this session and fold it into
+		// our known signers/nonces/partial sigs, then loop back around
+		msg, err := waitForMusigWrap(ctx, relay, sessionID, sec, ourPubkey)
+		if err != nil {
+			return false, fmt.Errorf("failed to receive next musig2 round for session %q: %w", sessionID, err)
+		}
+
+		sess.addContribution(msg)
+	}
+}
+
+// pubNonceFromSecNonce rebuilds the 66-byte public nonce from the base64
+// secret nonce performMusig hands back, using the same derivation btcec's
+// musig2 package uses internally (secNonceToPubNonce, musig2.go), so we can
+// broadcast our nonce without performMusig needing to return it directly.
+func pubNonceFromSecNonce(secNonce string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(secNonce)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret nonce: %w", err)
+	}
+	if len(b) != musig2.SecNonceSize {
+		return "", fmt.Errorf("secret nonce is not %d bytes", musig2.SecNonceSize)
+	}
+	var secNonce97 [musig2.SecNonceSize]byte
+	copy(secNonce97[:], b)
+	pubNonce := secNonceToPubNonce(secNonce97)
+	return hex.EncodeToString(pubNonce[:]), nil
+}
+
+// musigSession is the on-disk (and in-memory) record of everything
+// performMusigCoordinate knows about a running ceremony. The secret nonce
+// never leaves this file -- it is never printed or published anywhere.
+type musigSession struct {
+	SessionID   string       `json:"session_id"`
+	Event       *nostr.Event `json:"event"`
+	NumSigners  int          `json:"num_signers"`
+	OurPubkey   string       `json:"our_pubkey"`
+	OurSecNonce string       `json:"our_sec_nonce,omitempty"`
+	Pubkeys     []string     `json:"pubkeys"`
+	Nonces      []string     `json:"nonces"`
+	PartialSigs []string     `json:"partial_sigs"`
+}
+
+func (sess *musigSession) addContribution(msg musigWireMessage) {
+	hasPubkey := false
+	for _, pk := range sess.Pubkeys {
+		if pk == msg.Pubkey {
+			hasPubkey = true
+			break
+		}
+	}
+	if !hasPubkey {
+		sess.Pubkeys = append(sess.Pubkeys, msg.Pubkey)
+	}
+	if msg.Nonce != "" {
+		sess.Nonces = append(sess.Nonces, msg.Nonce)
+	}
+	if msg.PartialSig != "" {
+		sess.PartialSigs = append(sess.PartialSigs, msg.PartialSig)
+	}
+}
+
+func musigSessionsDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cache, "nak", "musig2-sessions")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func musigSessionPath(sessionID string) (string, error) {
+	dir, err := musigSessionsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionID+".json"), nil
+}
+
+func loadMusigSession(sessionID string) (*musigSession, error) {
+	path, err := musigSessionPath(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sess musigSession
+	if err := json.Unmarshal(b, &sess); err != nil {
+		return nil, fmt.Errorf("corrupt musig2 session file %s: %w", path, err)
+	}
+	return &sess, nil
+}
+
+func saveMusigSession(sess *musigSession) error {
+	path, err := musigSessionPath(sess.SessionID)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// musigDiscoverySecret derives a keypair from sessionID alone: every signer
+// who knows the session id (shared out-of-band, like a room code) can derive
+// the exact same keypair without having exchanged pubkeys first. We gift-wrap
+// every round's contribution to this key rather than to a specific peer, since
+// at session-open time (and often later) we don't yet know who the other
+// signers are -- there is no real recipient to address a NIP-59 wrap to.
+// This is not a secret in the cryptographic sense (anyone who learns the
+// session id can derive it too), it only exists to give the ceremony a
+// NIP-59-shaped discovery/broadcast channel.
+func musigDiscoverySecret(sessionID string) string {
+	h := sha256.Sum256([]byte("nak-musig2-coordinate:" + sessionID))
+	return hex.EncodeToString(h[:])
+}
+
+func musigDiscoveryPubkey(sessionID string) (string, error) {
+	secb, err := hex.DecodeString(musigDiscoverySecret(sessionID))
+	if err != nil {
+		return "", err
+	}
+	_, pubk := btcec.PrivKeyFromBytes(secb)
+	return hex.EncodeToString(pubk.SerializeCompressed()), nil
+}
+
+// publishMusigWrap seals msg into a rumor, gift-wraps it (NIP-59) with sec to
+// the session's discovery key, then publishes it to relay. What identifies us
+// to other signers is msg.Pubkey, carried inside the rumor's content. Our own
+// secret material is never part of msg.
+func publishMusigWrap(ctx context.Context, relay string, sessionID string, sec string, msg musigWireMessage) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	rumor := nostr.Event{
+		Kind:      musigCoordKind,
+		Content:   string(content),
+		CreatedAt: nostr.Now(),
+	}
+
+	discoveryPubkey, err := musigDiscoveryPubkey(sessionID)
+	if err != nil {
+		return err
+	}
+
+	wrap, err := nip59.GiftWrap(rumor, discoveryPubkey, sec)
+	if err != nil {
+		return err
+	}
+
+	for res := range sys.Pool.PublishMany(ctx, []string{relay}, *wrap) {
+		if res.Error != nil {
+			return fmt.Errorf("failed to publish to %s: %w", res.RelayURL, res.Error)
+		}
+	}
+
+	return nil
+}
+
+// waitForMusigWrap subscribes to relay for gift wraps addressed to the
+// session's discovery key, unwraps the first one that isn't authored by
+// ourselves and decodes its musigWireMessage. Skipping our own wraps matters:
+// since every signer (including us) derives and can unwrap the same discovery
+// key, without this check we'd eventually read back our own session-open or
+// round contribution and feed it into addContribution as if a new peer had
+// joined.
+func waitForMusigWrap(ctx context.Context, relay string, sessionID string, sec string, ourPubkey string) (musigWireMessage, error) {
+	discoverySec := musigDiscoverySecret(sessionID)
+	discoveryPubkey, err := musigDiscoveryPubkey(sessionID)
+	if err != nil {
+		return musigWireMessage{}, err
+	}
+
+	sub, err := sys.Pool.SubscribeMany(ctx, []string{relay}, nostr.Filter{
+		Kinds: []int{musigCoordKind},
+		Tags:  nostr.TagMap{"p": []string{discoveryPubkey}},
+	}, nostr.SubscriptionOptions{})
+	if err != nil {
+		return musigWireMessage{}, err
+	}
+
+	for ie := range sub {
+		rumor, err := nip59.Unwrap(ie.Event, discoverySec)
+		if err != nil {
+			// not a valid wrap for this session, keep waiting
+			continue
+		}
+		var msg musigWireMessage
+		if err := json.Unmarshal([]byte(rumor.Content), &msg); err != nil {
+			continue
+		}
+		if msg.SessionID != sessionID {
+			continue
+		}
+		if msg.Pubkey == ourPubkey {
+			// our own contribution looped back through the discovery channel
+			continue
+		}
+
+		return msg, nil
+	}
+
+	return musigWireMessage{}, fmt.Errorf("subscription ended before a new contribution arrived")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// musigStateFile is what --musig2-state <path> persists between invocations
+// of `nak event --musig2`, so the secret nonce never has to be printed to
+// stderr and copy-pasted back in with --musig2-nonce-secret.
+type musigStateFile struct {
+	MessageHash string   `json:"message_hash"` // the evt id or --musig2-message we're signing over
+	OurPubkey   string   `json:"our_pubkey"`
+	OurSecNonce string   `json:"our_sec_nonce,omitempty"`
+	Pubkeys     []string `json:"pubkeys"`
+	Nonces      []string `json:"nonces"`
+	PartialSigs []string `json:"partial_sigs"`
+	Round       int      `json:"round"`
+}
+
+// performMusigWithState wraps performMusig with a --musig2-state file: on
+// each call it loads whatever was persisted from the previous round, merges
+// in any newly-provided --musig2-pubkey/--musig2-nonce/--musig2-partial
+// values, runs the next state-machine step and rewrites the file atomically.
+// It refuses to reuse a secret nonce across two different message hashes,
+// since nonce reuse across messages leaks the secret key.
+func performMusigWithState(
+	ctx context.Context,
+	sec string,
+	evt *nostr.Event,
+	message string,
+	numSigners int,
+	extraPubkeys []string,
+	extraNonces []string,
+	extraPartialSigs []string,
+	tweaks []string,
+	tweaksXOnly bool,
+	statePath string,
+) (signed bool, err error) {
+	messageHash := message
+	if evt != nil {
+		messageHash = evt.GetID()
+	}
+
+	state, err := loadMusigState(statePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to load --musig2-state file %s: %w", statePath, err)
+	}
+
+	if state == nil {
+		state = &musigStateFile{MessageHash: messageHash}
+	} else if state.OurSecNonce != "" && state.MessageHash != messageHash {
+		return false, fmt.Errorf(
+			"refusing to reuse the secret nonce in %s for a different message (was signing %s, now %s): this would leak the secret key",
+			statePath, state.MessageHash, messageHash,
+		)
+	}
+
+	mergeMusigValues(&state.Pubkeys, extraPubkeys)
+	mergeMusigValues(&state.Nonces, extraNonces)
+	mergeMusigValues(&state.PartialSigs, extraPartialSigs)
+
+	signed, ourSecNonce, _, err := performMusig(
+		ctx, sec, evt, message, numSigners,
+		state.Pubkeys, state.Nonces, state.OurSecNonce, state.PartialSigs,
+		tweaks, tweaksXOnly,
+		true, // silent: nothing goes to stderr; the state file keeps our secret nonce and the values passed in, but not our own pub nonce or partial signature
+	)
+	if err != nil {
+		return false, err
+	}
+	if ourSecNonce != "" {
+		state.OurSecNonce = ourSecNonce
+	}
+	state.Round++
+
+	if err := saveMusigState(statePath, state); err != nil {
+		return false, fmt.Errorf("failed to save --musig2-state file %s: %w", statePath, err)
+	}
+
+	return signed, nil
+}
+
+// mergeMusigValues appends any values from extra that aren't already in known.
+func mergeMusigValues(known *[]string, extra []string) {
+	for _, v := range extra {
+		found := false
+		for _, k := range *known {
+			if k == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*known = append(*known, v)
+		}
+	}
+}
+
+func loadMusigState(path string) (*musigStateFile, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state musigStateFile
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("corrupt musig2 state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func saveMusigState(path string, state *musigStateFile) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeMusigValues(t *testing.T) {
+	known := []string{"a", "b"}
+	mergeMusigValues(&known, []string{"b", "c"})
+
+	want := []string{"a", "b", "c"}
+	if len(known) != len(want) {
+		t.Fatalf("mergeMusigValues = %v, want %v", known, want)
+	}
+	for i, v := range want {
+		if known[i] != v {
+			t.Fatalf("mergeMusigValues = %v, want %v", known, want)
+		}
+	}
+}
+
+func TestSaveLoadMusigStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	if s, err := loadMusigState(path); err != nil || s != nil {
+		t.Fatalf("loadMusigState on a missing file should return (nil, nil), got (%+v, %v)", s, err)
+	}
+
+	state := &musigStateFile{
+		MessageHash: "deadbeef",
+		OurPubkey:   "02aa",
+		OurSecNonce: "c2VjcmV0",
+		Pubkeys:     []string{"02aa", "02bb"},
+		Round:       1,
+	}
+	if err := saveMusigState(path, state); err != nil {
+		t.Fatalf("saveMusigState: %v", err)
+	}
+
+	got, err := loadMusigState(path)
+	if err != nil {
+		t.Fatalf("loadMusigState: %v", err)
+	}
+	if got.MessageHash != state.MessageHash || got.OurSecNonce != state.OurSecNonce || got.Round != state.Round {
+		t.Fatalf("loadMusigState round trip = %+v, want %+v", got, state)
+	}
+	if len(got.Pubkeys) != 2 || got.Pubkeys[1] != "02bb" {
+		t.Fatalf("loadMusigState round trip lost pubkeys: %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestMessageToCliArgs(t *testing.T) {
+	got := messageToCliArgs("aabbcc")
+	want := "--musig2-message aabbcc"
+	if got != want {
+		t.Fatalf("messageToCliArgs = %q, want %q", got, want)
+	}
+}
+
+func TestTweaksToCliArgs(t *testing.T) {
+	if got := tweaksToCliArgs(nil, false); got != "" {
+		t.Fatalf("tweaksToCliArgs(nil) = %q, want empty", got)
+	}
+
+	got := tweaksToCliArgs([]string{"aa", "bb"}, false)
+	want := " --musig2-tweak aa --musig2-tweak bb"
+	if got != want {
+		t.Fatalf("tweaksToCliArgs = %q, want %q", got, want)
+	}
+
+	got = tweaksToCliArgs([]string{"aa"}, true)
+	want = " --musig2-tweak aa --musig2-tweak-xonly"
+	if got != want {
+		t.Fatalf("tweaksToCliArgs(xonly) = %q, want %q", got, want)
+	}
+
+	// the xonly flag only makes sense alongside actual tweaks
+	if got := tweaksToCliArgs(nil, true); got != "" {
+		t.Fatalf("tweaksToCliArgs(nil, xonly) = %q, want empty", got)
+	}
+}